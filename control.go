@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package daemonize
+
+import (
+	"fmt"
+
+	"github.com/PlakarKorp/go-daemonize/logging"
+)
+
+// logLevelByName maps the level names accepted on the control socket to
+// their logging.LogLevel: the lower-case form of LogLevel.String.
+var logLevelByName = map[string]logging.LogLevel{
+	"none":  logging.NONE,
+	"fatal": logging.FATAL,
+	"error": logging.ERROR,
+	"warn":  logging.WARN,
+	"info":  logging.INFO,
+	"debug": logging.DEBUG,
+	"all":   logging.ALL,
+}
+
+// Daemon implements control.Handler, so it can be served directly over the
+// socket set up via WithControlSocket.
+
+func (daemon *Daemon) LogLevel(name, level string) error {
+	lvl, ok := logLevelByName[level]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	ctrl, found := daemon.services[name]
+	if !found {
+		return fmt.Errorf("no such service: %s", name)
+	}
+	return ctrl.SetLevel(lvl)
+}
+
+func (daemon *Daemon) Status() map[string]string {
+	status := make(map[string]string, len(daemon.services))
+	for name, ctrl := range daemon.services {
+		status[name] = string(ctrl.Status())
+	}
+	return status
+}
+
+func (daemon *Daemon) Services() []string {
+	names := make([]string, 0, len(daemon.services))
+	for name := range daemon.services {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (daemon *Daemon) Stop(name string) error {
+	ctrl, found := daemon.services[name]
+	if !found {
+		return fmt.Errorf("no such service: %s", name)
+	}
+	ctrl.stopService(daemon.stopGrace, stoppedForGood)
+	return nil
+}
+
+func (daemon *Daemon) Start(name string) error {
+	ctrl, found := daemon.services[name]
+	if !found {
+		return fmt.Errorf("no such service: %s", name)
+	}
+	return ctrl.startService(daemon.ctx, &daemon.wg)
+}
+
+func (daemon *Daemon) Reload() error {
+	return daemon.reloadConfig(daemon.ctx, logging.GetLogger(daemon.ctx))
+}
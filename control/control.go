@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package control implements the line-based protocol spoken on a daemon's
+// control socket, letting an operator inspect and manage services at
+// runtime without restarting the process.
+package control
+
+// Handler is implemented by whatever owns the services a control socket
+// manages. Errors returned from these methods are reported back to the
+// caller as-is.
+type Handler interface {
+	// LogLevel sets the log level of a single service.
+	LogLevel(service, level string) error
+	// Status returns the status of every known service, keyed by name.
+	Status() map[string]string
+	// Services lists the names of every known service.
+	Services() []string
+	// Stop stops a running service.
+	Stop(service string) error
+	// Start starts a stopped service.
+	Start(service string) error
+	// Reload asks the daemon to reload its configuration.
+	Reload() error
+}
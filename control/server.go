@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package control
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Server listens on a UNIX socket and dispatches line-protocol commands to
+// a Handler. Each line is a command name followed by whitespace-separated
+// arguments; each response is a single line starting with "OK" or "ERROR".
+type Server struct {
+	path     string
+	handler  Handler
+	listener net.Listener
+}
+
+// NewServer creates a control Server that will listen on path and dispatch
+// commands to handler.
+func NewServer(path string, handler Handler) *Server {
+	return &Server{path: path, handler: handler}
+}
+
+// ListenAndServe binds the control socket and starts accepting connections
+// in the background. It returns once the socket is ready to accept.
+func (s *Server) ListenAndServe() error {
+	os.Remove(s.path)
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("control: listen on %s: %w", s.path, err)
+	}
+	s.listener = listener
+
+	go s.acceptLoop()
+	return nil
+}
+
+// Close stops accepting new connections and removes the socket file.
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
+
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fmt.Fprintln(conn, s.dispatch(line))
+	}
+}
+
+func (s *Server) dispatch(line string) string {
+	args := strings.Fields(line)
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "loglevel":
+		if len(args) != 2 {
+			return "ERROR: usage: loglevel <service> <level>"
+		}
+		if err := s.handler.LogLevel(args[0], args[1]); err != nil {
+			return "ERROR: " + err.Error()
+		}
+		return "OK"
+
+	case "status":
+		status := s.handler.Status()
+		names := make([]string, 0, len(status))
+		for name := range status {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var b strings.Builder
+		b.WriteString("OK")
+		for _, name := range names {
+			fmt.Fprintf(&b, " %s=%s", name, status[name])
+		}
+		return b.String()
+
+	case "services":
+		names := s.handler.Services()
+		sort.Strings(names)
+		return "OK " + strings.Join(names, " ")
+
+	case "stop":
+		if len(args) != 1 {
+			return "ERROR: usage: stop <service>"
+		}
+		if err := s.handler.Stop(args[0]); err != nil {
+			return "ERROR: " + err.Error()
+		}
+		return "OK"
+
+	case "start":
+		if len(args) != 1 {
+			return "ERROR: usage: start <service>"
+		}
+		if err := s.handler.Start(args[0]); err != nil {
+			return "ERROR: " + err.Error()
+		}
+		return "OK"
+
+	case "reload":
+		if err := s.handler.Reload(); err != nil {
+			return "ERROR: " + err.Error()
+		}
+		return "OK"
+
+	default:
+		return fmt.Sprintf("ERROR: unknown command %q", cmd)
+	}
+}
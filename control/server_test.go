@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package control
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeHandler is a Handler whose behavior is fully controlled by the test,
+// so dispatch can be exercised without a real daemon or socket.
+type fakeHandler struct {
+	logLevelErr error
+	status      map[string]string
+	services    []string
+	stopErr     error
+	startErr    error
+	reloadErr   error
+
+	gotService, gotLevel string
+}
+
+func (h *fakeHandler) LogLevel(service, level string) error {
+	h.gotService, h.gotLevel = service, level
+	return h.logLevelErr
+}
+
+func (h *fakeHandler) Status() map[string]string  { return h.status }
+func (h *fakeHandler) Services() []string         { return h.services }
+func (h *fakeHandler) Stop(service string) error  { h.gotService = service; return h.stopErr }
+func (h *fakeHandler) Start(service string) error { h.gotService = service; return h.startErr }
+func (h *fakeHandler) Reload() error              { return h.reloadErr }
+
+func TestDispatchLogLevel(t *testing.T) {
+	h := &fakeHandler{}
+	s := NewServer("", h)
+
+	if got := s.dispatch("loglevel worker debug"); got != "OK" {
+		t.Fatalf("dispatch() = %q, want OK", got)
+	}
+	if h.gotService != "worker" || h.gotLevel != "debug" {
+		t.Fatalf("LogLevel called with (%q, %q), want (worker, debug)", h.gotService, h.gotLevel)
+	}
+
+	if got := s.dispatch("loglevel worker"); got != "ERROR: usage: loglevel <service> <level>" {
+		t.Fatalf("dispatch() = %q, want usage error", got)
+	}
+
+	h.logLevelErr = errors.New("unknown log level")
+	if got := s.dispatch("loglevel worker bogus"); got != "ERROR: unknown log level" {
+		t.Fatalf("dispatch() = %q, want wrapped handler error", got)
+	}
+}
+
+func TestDispatchStatus(t *testing.T) {
+	h := &fakeHandler{status: map[string]string{"b": "up", "a": "down"}}
+	s := NewServer("", h)
+
+	if got := s.dispatch("status"); got != "OK a=down b=up" {
+		t.Fatalf("dispatch() = %q, want sorted status line", got)
+	}
+}
+
+func TestDispatchServices(t *testing.T) {
+	h := &fakeHandler{services: []string{"b", "a"}}
+	s := NewServer("", h)
+
+	if got := s.dispatch("services"); got != "OK a b" {
+		t.Fatalf("dispatch() = %q, want sorted service list", got)
+	}
+}
+
+func TestDispatchStopStart(t *testing.T) {
+	h := &fakeHandler{}
+	s := NewServer("", h)
+
+	if got := s.dispatch("stop worker"); got != "OK" || h.gotService != "worker" {
+		t.Fatalf("dispatch(stop) = %q, service = %q", got, h.gotService)
+	}
+	if got := s.dispatch("start"); got != "ERROR: usage: start <service>" {
+		t.Fatalf("dispatch() = %q, want usage error", got)
+	}
+
+	h.startErr = errors.New("no such service: worker")
+	if got := s.dispatch("start worker"); got != "ERROR: no such service: worker" {
+		t.Fatalf("dispatch() = %q, want wrapped handler error", got)
+	}
+}
+
+func TestDispatchReload(t *testing.T) {
+	h := &fakeHandler{}
+	s := NewServer("", h)
+
+	if got := s.dispatch("reload"); got != "OK" {
+		t.Fatalf("dispatch() = %q, want OK", got)
+	}
+
+	h.reloadErr = errors.New("reload failed")
+	if got := s.dispatch("reload"); got != "ERROR: reload failed" {
+		t.Fatalf("dispatch() = %q, want wrapped handler error", got)
+	}
+}
+
+func TestDispatchUnknownCommand(t *testing.T) {
+	s := NewServer("", &fakeHandler{})
+
+	if got := s.dispatch("frobnicate"); got != `ERROR: unknown command "frobnicate"` {
+		t.Fatalf("dispatch() = %q, want unknown command error", got)
+	}
+}
@@ -17,37 +17,83 @@
 package daemonize
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log/syslog"
+	"net"
 	"os"
 	"path/filepath"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/PlakarKorp/go-daemonize/control"
 	"github.com/PlakarKorp/go-daemonize/logging"
+	"github.com/PlakarKorp/go-daemonize/reload"
+	"github.com/PlakarKorp/go-daemonize/systemd"
 )
 
+// Configuration is parsed once at startup from the -config file, and
+// re-parsed on SIGHUP (see reloadConfig). Validate is called on every
+// reload before the new value is swapped in, and Diff lets the reload
+// log what changed without the caller having to know its fields.
 type Configuration interface {
 	Parse(rd io.Reader) error
+	Validate() error
+	Diff(prev Configuration) []reload.ConfigChange
 }
 
 type Daemon struct {
-	name    string
-	version string
-	logTag  string
-	config  Configuration
-	isDebug bool
+	name          string
+	version       string
+	logTag        string
+	config        Configuration
+	configFile    string
+	configMu      sync.Mutex
+	isDebug       bool
+	controlSocket string
+	useSystemd    bool
 
-	wg       sync.WaitGroup
-	services map[string]Service
+	logRotateMaxBytes  int64
+	logRotateMaxAge    time.Duration
+	logRotateKeep      int
+	logAsyncBuffer     int
+	logAsyncDropOnFull bool
+
+	stopGrace         time.Duration
+	restartBaseDelay  time.Duration
+	restartMaxDelay   time.Duration
+	restartMaxRetries int
+
+	wg           sync.WaitGroup
+	services     map[string]*ServiceController
+	depGraph     map[string][]string
+	serviceOrder []string
+	control      *control.Server
+	ctx          context.Context
+	notifier     *systemd.Notifier
+	listeners    map[string]net.Listener
+	logReopener  interface{ Reopen() error }
+	logFlusher   io.Closer
 }
 
+// Defaults used by the restart backoff and shutdown grace logic when the
+// corresponding Option wasn't used to override them.
+const (
+	defaultStopGrace        = 10 * time.Second
+	defaultRestartBaseDelay = 500 * time.Millisecond
+	defaultRestartMaxDelay  = 30 * time.Second
+)
+
 type Option func(*Daemon)
 
 func NewDaemon(opts ...Option) *Daemon {
-	d := &Daemon{}
+	d := &Daemon{
+		stopGrace:         defaultStopGrace,
+		restartMaxRetries: -1,
+	}
 
 	for _, opt := range opts {
 		opt(d)
@@ -72,15 +118,117 @@ func WithConfiguration(config Configuration) Option {
 	return func(d *Daemon) { d.config = config }
 }
 
+// WithControlSocket makes the daemon listen on a UNIX control socket at
+// path, accepting commands to inspect and manage services at runtime
+// (see the control package for the protocol).
+func WithControlSocket(path string) Option {
+	return func(d *Daemon) { d.controlSocket = path }
+}
+
+// WithSystemd enables systemd integration: when NOTIFY_SOCKET or
+// LISTEN_FDS are present in the environment, the daemon skips its usual
+// fork+exec, sends READY=1/STATUS/WATCHDOG=1 notifications as services
+// come up, and makes sockets inherited via socket activation available
+// through ListenerFor.
+func WithSystemd() Option {
+	return func(d *Daemon) { d.useSystemd = true }
+}
+
+// WithLogRotation rotates the -log file once it exceeds maxBytes or
+// maxAge, keeping at most keep rotated copies around (zero disables that
+// particular trigger or limit).
+func WithLogRotation(maxBytes int64, maxAge time.Duration, keep int) Option {
+	return func(d *Daemon) {
+		d.logRotateMaxBytes = maxBytes
+		d.logRotateMaxAge = maxAge
+		d.logRotateKeep = keep
+	}
+}
+
+// WithAsyncLogging makes the -log file writer asynchronous: entries are
+// queued to a channel of bufferSize and flushed by a background goroutine
+// instead of blocking the logging call on disk I/O. When the buffer fills
+// up, entries are dropped (and counted) if dropOnFull is set, or the
+// logging call blocks otherwise.
+func WithAsyncLogging(bufferSize int, dropOnFull bool) Option {
+	return func(d *Daemon) {
+		d.logAsyncBuffer = bufferSize
+		d.logAsyncDropOnFull = dropOnFull
+	}
+}
+
+// WithStopGrace bounds how long Daemon.Run waits for each service to
+// return, in reverse topological order, before moving on to stop the
+// next one at shutdown.
+func WithStopGrace(grace time.Duration) Option {
+	return func(d *Daemon) { d.stopGrace = grace }
+}
+
+// WithRestartBackoff configures the exponential backoff used to restart
+// a service that exits and whose RestartPolicy calls for it: the delay
+// doubles from base up to max on each successive attempt, and a service
+// is given up on (left down) after maxRetries consecutive attempts. A
+// negative maxRetries means retry indefinitely, which is the default.
+func WithRestartBackoff(base, max time.Duration, maxRetries int) Option {
+	return func(d *Daemon) {
+		d.restartBaseDelay = base
+		d.restartMaxDelay = max
+		d.restartMaxRetries = maxRetries
+	}
+}
+
+// ListenerFor returns a listener inherited via systemd socket activation,
+// named by FileDescriptorName= in the unit (or by its positional index,
+// e.g. "0", if unnamed). It returns an error if WithSystemd wasn't used or
+// no such socket was handed to this process.
+func (daemon *Daemon) ListenerFor(name string) (net.Listener, error) {
+	l, found := daemon.listeners[name]
+	if !found {
+		return nil, fmt.Errorf("no systemd socket named %q", name)
+	}
+	return l, nil
+}
+
+func (daemon *Daemon) systemdManaged() bool {
+	return daemon.useSystemd && (daemon.notifier.Enabled() || systemd.Activated())
+}
+
+// reopenLogs closes and reopens the -log file, for compatibility with
+// external logrotate(8) setups that rename it out from under us and
+// expect a SIGHUP to make us pick up the new one.
+func (daemon *Daemon) reopenLogs(logger logging.Logger) {
+	if daemon.logReopener == nil {
+		return
+	}
+	if err := daemon.logReopener.Reopen(); err != nil {
+		logger.Error("failed to reopen log file: %v", err)
+		return
+	}
+	logger.Info("reopened log file")
+}
+
 func (daemon *Daemon) IsDebugMode() bool {
 	return daemon.isDebug
 }
 
+// Configuration returns the daemon's current, live Configuration. Its
+// value may change across a call to this method if a SIGHUP reload
+// happens concurrently (see reloadConfig); services that need a
+// consistent view across several fields should instead rely on the cfg
+// passed to their Reload method.
+func (daemon *Daemon) Configuration() Configuration {
+	daemon.configMu.Lock()
+	defer daemon.configMu.Unlock()
+	return daemon.config
+}
+
 func (daemon *Daemon) setUp() {
 	var opt_version bool
 	var opt_configFile string
 	var opt_foreground bool
 	var opt_logFile string
+	var opt_logFormat string
+	var opt_vmodule string
 
 	// Parse cmdline parameters
 	flag.StringVar(&opt_configFile, "config", "", "configuration file")
@@ -88,6 +236,8 @@ func (daemon *Daemon) setUp() {
 	flag.BoolVar(&daemon.isDebug, "debug", false, "debug mode")
 	flag.BoolVar(&opt_foreground, "foreground", false, "run in foreground")
 	flag.StringVar(&opt_logFile, "log", "", "log file")
+	flag.StringVar(&opt_logFormat, "log-format", "text", "log format: text, json or logfmt")
+	flag.StringVar(&opt_vmodule, "vmodule", "", "per-file/package verbosity overrides (file=N,pkg/*=N)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS]\n", flag.CommandLine.Name())
@@ -108,6 +258,23 @@ func (daemon *Daemon) setUp() {
 		os.Exit(0)
 	}
 
+	if opt_vmodule != "" {
+		if err := logging.SetVModule(opt_vmodule); err != nil {
+			logging.Fatal("invalid -vmodule: %v", err)
+		}
+	}
+
+	if daemon.useSystemd {
+		daemon.notifier = systemd.NewNotifier()
+		listeners, err := systemd.Listeners()
+		if err != nil {
+			logging.Fatal("failed to collect systemd sockets: %v", err)
+		}
+		daemon.listeners = listeners
+	} else {
+		daemon.notifier = &systemd.Notifier{}
+	}
+
 	// Read configuration
 	if daemon.config != nil {
 		if opt_configFile == "" {
@@ -122,10 +289,17 @@ func (daemon *Daemon) setUp() {
 		if err := daemon.config.Parse(fp); err != nil {
 			logging.Fatal("failed to parse config file: %v", err)
 		}
+		if err := daemon.config.Validate(); err != nil {
+			logging.Fatal("invalid configuration: %v", err)
+		}
+		daemon.configFile = opt_configFile
 	}
 
-	// Do fork+exec if needed
-	if !opt_foreground && os.Getenv("REEXEC") == "" {
+	// Do fork+exec if needed, unless systemd is already supervising us
+	// (Type=notify/socket units run their command directly, in the
+	// foreground, with stdout/stderr captured by journald).
+	managedBySystemd := daemon.systemdManaged()
+	if !opt_foreground && !managedBySystemd && os.Getenv("REEXEC") == "" {
 		pid, err := daemon.doDaemonize(os.Args)
 		if err != nil {
 			logging.Fatal("failed to rexec: %v", err)
@@ -136,17 +310,48 @@ func (daemon *Daemon) setUp() {
 
 	// Setup logging
 	if opt_logFile != "" {
-		logger, err := logging.NewFileLogger(opt_logFile)
+		rotating, err := logging.NewRotatingWriter(opt_logFile, daemon.logRotateMaxBytes, daemon.logRotateMaxAge, daemon.logRotateKeep)
 		if err != nil {
 			logging.Fatal("cannot open log file: %v", err)
 		}
-		logging.SetDefaultLogger(logger)
-	} else if !opt_foreground {
+		daemon.logReopener = rotating
+
+		var writer io.Writer = rotating
+		if daemon.logAsyncBuffer > 0 {
+			async := logging.NewAsyncWriter(writer, daemon.logAsyncBuffer, daemon.logAsyncDropOnFull)
+			daemon.logFlusher = async
+			writer = async
+		}
+		logging.SetDefaultLogger(withLogFormat(logging.NewWriterLogger(writer, true), opt_logFormat))
+	} else if !opt_foreground && !managedBySystemd {
 		logger, err := logging.NewSyslogLogger(syslog.LOG_INFO|syslog.LOG_USER, daemon.logTag)
 		if err != nil {
 			logging.Fatal("cannot open syslog: %v", err)
 		}
-		logging.SetDefaultLogger(logger)
+		logging.SetDefaultLogger(withLogFormat(logger, opt_logFormat))
+	} else {
+		logging.SetDefaultLogger(withLogFormat(logging.GetDefaultLogger(), opt_logFormat))
+	}
+}
+
+// formatterSetter is implemented by Logger concrete types that support
+// swapping their LogFormatter, e.g. via -log-format.
+type formatterSetter interface {
+	WithFormatter(formatter logging.LogFormatter) logging.Logger
+}
+
+func withLogFormat(logger logging.Logger, format string) logging.Logger {
+	setter, ok := logger.(formatterSetter)
+	if !ok {
+		return logger
+	}
+	switch format {
+	case "json":
+		return setter.WithFormatter(&logging.JSONFormatter{})
+	case "logfmt":
+		return setter.WithFormatter(&logging.LogfmtFormatter{})
+	default:
+		return logger
 	}
 }
 
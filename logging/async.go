@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package logging
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// AsyncWriter queues writes to a bounded channel and flushes them from a
+// single background goroutine, so that slow disks don't block whatever is
+// logging. When dropOnFull is set, writes that arrive while the buffer is
+// full are discarded (and counted) rather than blocking the caller.
+type AsyncWriter struct {
+	dest       io.Writer
+	queue      chan []byte
+	dropOnFull bool
+	dropped    atomic.Int64
+	done       chan struct{}
+}
+
+// NewAsyncWriter starts a background goroutine that writes to dest every
+// entry enqueued through the returned AsyncWriter.
+func NewAsyncWriter(dest io.Writer, bufferSize int, dropOnFull bool) *AsyncWriter {
+	w := &AsyncWriter{
+		dest:       dest,
+		queue:      make(chan []byte, bufferSize),
+		dropOnFull: dropOnFull,
+		done:       make(chan struct{}),
+	}
+	go w.drain()
+	return w
+}
+
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	entry := append([]byte(nil), p...)
+	if w.dropOnFull {
+		select {
+		case w.queue <- entry:
+		default:
+			w.dropped.Add(1)
+		}
+		return len(p), nil
+	}
+	w.queue <- entry
+	return len(p), nil
+}
+
+// Dropped returns how many entries were discarded because the buffer was
+// full. It is always zero unless dropOnFull was set.
+func (w *AsyncWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+func (w *AsyncWriter) drain() {
+	defer close(w.done)
+	var batch []byte
+	for entry := range w.queue {
+		batch = append(batch, entry...)
+	drainMore:
+		for {
+			select {
+			case entry, ok := <-w.queue:
+				if !ok {
+					break drainMore
+				}
+				batch = append(batch, entry...)
+			default:
+				break drainMore
+			}
+		}
+		w.dest.Write(batch)
+		batch = batch[:0]
+	}
+}
+
+// Close stops accepting new entries, waits for the queue to drain, and
+// closes dest if it supports it.
+func (w *AsyncWriter) Close() error {
+	close(w.queue)
+	<-w.done
+	if closer, ok := w.dest.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Field is a single structured key/value attribute attached to a LogEntry.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// LogEntry carries everything a LogFormatter needs to render one log line.
+type LogEntry struct {
+	Timestamp time.Time
+	Level     LogLevel
+	Message   string
+	Args      []any
+	Fields    []Field
+	Caller    string
+}
+
+// LogFormatter renders a LogEntry to w. Implementations must not retain
+// entry beyond the call.
+type LogFormatter interface {
+	FormatLog(w io.Writer, entry *LogEntry)
+}
+
+func (l LogLevel) String() string {
+	switch l {
+	case NONE:
+		return "NONE"
+	case FATAL:
+		return "FATAL"
+	case ERROR:
+		return "ERROR"
+	case WARN:
+		return "WARN"
+	case INFO:
+		return "INFO"
+	case DEBUG:
+		return "DEBUG"
+	case ALL:
+		return "ALL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func renderMessage(entry *LogEntry) string {
+	if len(entry.Args) == 0 {
+		return entry.Message
+	}
+	return fmt.Sprintf(entry.Message, entry.Args...)
+}
+
+// DefaultFormatter renders plain "LEVEL: message key=value ..." text lines.
+// A prefix set with WithPrefix is one of those fields (prefix=...), at the
+// end of the line rather than before the message.
+type DefaultFormatter struct{}
+
+func (f *DefaultFormatter) FormatLog(w io.Writer, entry *LogEntry) {
+	var buf bytes.Buffer
+	buf.WriteString(entry.Level.String())
+	buf.WriteString(": ")
+	buf.WriteString(renderMessage(entry))
+	for _, field := range entry.Fields {
+		fmt.Fprintf(&buf, " %s=%v", field.Key, field.Value)
+	}
+	w.Write(buf.Bytes())
+}
+
+// JSONFormatter renders one JSON object per entry, with ts, level, msg,
+// caller and any structured fields merged in as top-level keys.
+type JSONFormatter struct{}
+
+func (f *JSONFormatter) FormatLog(w io.Writer, entry *LogEntry) {
+	obj := make(map[string]any, 4+len(entry.Fields))
+	obj["ts"] = entry.Timestamp.Format(time.RFC3339Nano)
+	obj["level"] = entry.Level.String()
+	obj["msg"] = renderMessage(entry)
+	if entry.Caller != "" {
+		obj["caller"] = entry.Caller
+	}
+	for _, field := range entry.Fields {
+		obj[field.Key] = field.Value
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		fmt.Fprintf(w, `{"level":"ERROR","msg":"failed to marshal log entry: %v"}`, err)
+		return
+	}
+	w.Write(data)
+}
+
+// LogfmtFormatter renders logfmt-style "key=value" lines, as consumed by
+// tools like heroku's log router or journalctl's logfmt filters.
+type LogfmtFormatter struct{}
+
+func (f *LogfmtFormatter) FormatLog(w io.Writer, entry *LogEntry) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "ts=%s level=%s msg=%q", entry.Timestamp.Format(time.RFC3339Nano), entry.Level, renderMessage(entry))
+	for _, field := range entry.Fields {
+		fmt.Fprintf(&buf, " %s=%v", field.Key, field.Value)
+	}
+	w.Write(buf.Bytes())
+}
+
+// fieldsFromArgs turns an alternating key/value variadic list into Fields,
+// skipping a trailing odd key with no value.
+func fieldsFromArgs(kv []any) []Field {
+	fields := make([]Field, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", kv[i])
+		}
+		fields = append(fields, Field{Key: key, Value: kv[i+1]})
+	}
+	return fields
+}
@@ -18,9 +18,12 @@ package logging
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log/syslog"
 	"os"
+	"path/filepath"
+	"runtime"
 	"time"
 )
 
@@ -28,6 +31,7 @@ type logger struct {
 	level       LogLevel
 	formatter   LogFormatter
 	addLineFeed bool
+	fields      []Field
 	debug       io.Writer
 	info        io.Writer
 	warn        io.Writer
@@ -35,6 +39,19 @@ type logger struct {
 	fatal       io.Writer
 }
 
+// callerInfo reports "file:line" for whoever called the exported logging
+// method (Info, Warnw, ...) that led here, three frames above this one:
+// callerInfo -> doLog/doLogw -> Debug/Info/... -> the actual call site.
+// Only the base file name is kept, matching the short form vmodule rules
+// already key off (see logging/verbosity.go).
+func callerInfo() string {
+	_, file, line, ok := runtime.Caller(3)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
+
 func (l *logger) doLog(w io.Writer, level LogLevel, message string, args ...any) {
 	if l.level < level {
 		return
@@ -44,6 +61,28 @@ func (l *logger) doLog(w io.Writer, level LogLevel, message string, args ...any)
 		Level:     level,
 		Message:   message,
 		Args:      args,
+		Fields:    l.fields,
+		Caller:    callerInfo(),
+	}
+
+	var buf bytes.Buffer
+	l.formatter.FormatLog(&buf, &entry)
+	if l.addLineFeed {
+		buf.WriteRune('\n')
+	}
+	w.Write(buf.Bytes())
+}
+
+func (l *logger) doLogw(w io.Writer, level LogLevel, message string, kv ...any) {
+	if l.level < level {
+		return
+	}
+	entry := LogEntry{
+		Timestamp: time.Now(),
+		Level:     level,
+		Message:   message,
+		Fields:    append(append([]Field{}, l.fields...), fieldsFromArgs(kv)...),
+		Caller:    callerInfo(),
 	}
 
 	var buf bytes.Buffer
@@ -75,6 +114,37 @@ func (l *logger) Fatal(format string, args ...any) {
 	os.Exit(1)
 }
 
+func (l *logger) Debugw(msg string, kv ...any) {
+	l.doLogw(l.debug, DEBUG, msg, kv...)
+}
+
+func (l *logger) Infow(msg string, kv ...any) {
+	l.doLogw(l.info, INFO, msg, kv...)
+}
+
+func (l *logger) Warnw(msg string, kv ...any) {
+	l.doLogw(l.warn, WARN, msg, kv...)
+}
+
+func (l *logger) Errorw(msg string, kv ...any) {
+	l.doLogw(l.error, ERROR, msg, kv...)
+}
+
+func (l *logger) With(kv ...any) Logger {
+	var new logger
+	new = *l
+	new.fields = append(append([]Field{}, l.fields...), fieldsFromArgs(kv)...)
+	return &new
+}
+
+func (l *logger) SetLevel(level LogLevel) {
+	l.level = level
+}
+
+func (l *logger) V(level int) Verbose {
+	return Verbose{enabled: vEnabledAt(level, 2), logger: l}
+}
+
 func (l *logger) WithLevel(level LogLevel) Logger {
 	var new logger
 	new = *l
@@ -89,6 +159,16 @@ func (l *logger) WithFormatter(formatter LogFormatter) Logger {
 	return &new
 }
 
+func (l *logger) WithPrefix(prefix string, args ...any) Logger {
+	if len(args) > 0 {
+		prefix = fmt.Sprintf(prefix, args...)
+	}
+	var new logger
+	new = *l
+	new.fields = append(append([]Field{}, l.fields...), Field{Key: "prefix", Value: prefix})
+	return &new
+}
+
 func NewDefaultLogger() Logger {
 	return &logger{
 		level:       INFO,
@@ -39,6 +39,22 @@ type Logger interface {
 	Warn(format string, args ...any)
 	Error(format string, args ...any)
 	Fatal(format string, args ...any)
+
+	// Debugw, Infow, Warnw and Errorw log a static message along with
+	// structured key/value attributes, e.g. Infow("connected", "addr", addr).
+	Debugw(msg string, kv ...any)
+	Infow(msg string, kv ...any)
+	Warnw(msg string, kv ...any)
+	Errorw(msg string, kv ...any)
+
+	// With returns a Logger that merges kv into the attributes of every
+	// entry it logs, in addition to its own.
+	With(kv ...any) Logger
+
+	// V returns a Verbose gate for level, enabled by a matching -vmodule
+	// rule for the file calling V. Disabled gates are near-free: their
+	// methods short-circuit before touching the formatter.
+	V(level int) Verbose
 }
 
 var defaultLogger Logger = NewDefaultLogger()
@@ -75,6 +91,26 @@ func Fatal(format string, args ...any) {
 	defaultLogger.Fatal(format, args...)
 }
 
+func Debugw(msg string, kv ...any) {
+	defaultLogger.Debugw(msg, kv...)
+}
+
+func Infow(msg string, kv ...any) {
+	defaultLogger.Infow(msg, kv...)
+}
+
+func Warnw(msg string, kv ...any) {
+	defaultLogger.Warnw(msg, kv...)
+}
+
+func Errorw(msg string, kv ...any) {
+	defaultLogger.Errorw(msg, kv...)
+}
+
+func With(kv ...any) Logger {
+	return defaultLogger.With(kv...)
+}
+
 /* Contextualized logger */
 
 type key int
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RotatingWriter is an io.Writer over a log file that rotates it once it
+// grows past maxBytes or gets older than maxAge, keeping at most keep
+// rotated copies around. A zero maxBytes, maxAge or keep disables that
+// particular trigger/limit.
+type RotatingWriter struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	maxAge   time.Duration
+	keep     int
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingWriter opens (or creates) path and returns a RotatingWriter
+// that manages its rotation.
+func NewRotatingWriter(path string, maxBytes int64, maxAge time.Duration, keep int) (*RotatingWriter, error) {
+	w := &RotatingWriter{
+		path:     path,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		keep:     keep,
+	}
+	if err := w.openCurrent(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) openCurrent() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = info.ModTime()
+	return nil
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingWriter) shouldRotate(nextWrite int) bool {
+	if w.maxBytes > 0 && w.size+int64(nextWrite) > w.maxBytes {
+		return true
+	}
+	if w.maxAge > 0 && time.Since(w.openedAt) > w.maxAge {
+		return true
+	}
+	return false
+}
+
+func (w *RotatingWriter) rotate() error {
+	if w.file != nil {
+		w.file.Sync()
+		w.file.Close()
+	}
+
+	rotated := fmt.Sprintf("%s.%s", w.path, time.Now().Format("20060102T150405.000000000"))
+	if err := os.Rename(w.path, rotated); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := w.openCurrent(); err != nil {
+		return err
+	}
+	return w.prune()
+}
+
+func (w *RotatingWriter) prune() error {
+	if w.keep <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+	if len(matches) <= w.keep {
+		return nil
+	}
+	for _, old := range matches[:len(matches)-w.keep] {
+		os.Remove(old)
+	}
+	return nil
+}
+
+// Reopen closes and reopens the log file at its configured path. It is
+// meant to be called after an external logrotate(8) has renamed the file
+// out from under us, so that subsequent writes land in a fresh file.
+func (w *RotatingWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		w.file.Close()
+	}
+	return w.openCurrent()
+}
+
+func (w *RotatingWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Verbose is returned by Logger.V(level) and is cheap to discard: when the
+// level is disabled for the calling file, its methods do nothing and never
+// touch the formatter.
+type Verbose struct {
+	enabled bool
+	logger  Logger
+}
+
+// Enabled reports whether this verbosity level is active for the file that
+// called V, letting callers guard expensive argument construction
+// themselves: if v := logger.V(2); v.Enabled() { ... }.
+func (v Verbose) Enabled() bool {
+	return v.enabled
+}
+
+func (v Verbose) Info(format string, args ...any) {
+	if v.enabled {
+		v.logger.Info(format, args...)
+	}
+}
+
+func (v Verbose) Infow(msg string, kv ...any) {
+	if v.enabled {
+		v.logger.Infow(msg, kv...)
+	}
+}
+
+type vmoduleRule struct {
+	pattern string
+	level   int
+}
+
+var (
+	vmoduleMu    sync.RWMutex
+	vmoduleRules []vmoduleRule
+	vmoduleCache sync.Map // uintptr (pc) -> int (resolved level, or -1 for "no rule")
+)
+
+// SetVModule installs per-file/per-package verbosity overrides from a
+// glog-style spec, e.g. "worker=2,plugins/*=1". A bare name matches the
+// base name of a source file (without its .go extension); a name ending in
+// "/*" matches every file under that package directory.
+func SetVModule(spec string) error {
+	var rules []vmoduleRule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, levelStr, found := strings.Cut(part, "=")
+		if !found {
+			return fmt.Errorf("logging: invalid vmodule entry %q", part)
+		}
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			return fmt.Errorf("logging: invalid vmodule level in %q: %w", part, err)
+		}
+		rules = append(rules, vmoduleRule{pattern: name, level: level})
+	}
+
+	vmoduleMu.Lock()
+	vmoduleRules = rules
+	vmoduleMu.Unlock()
+	vmoduleCache.Range(func(key, _ any) bool {
+		vmoduleCache.Delete(key)
+		return true
+	})
+	return nil
+}
+
+// vEnabledAt reports whether level is enabled for the call site skip frames
+// above it, resolving and caching the decision per call site (per pc).
+func vEnabledAt(level, skip int) bool {
+	pc, file, _, ok := runtime.Caller(skip)
+	if !ok {
+		return false
+	}
+
+	if cached, found := vmoduleCache.Load(pc); found {
+		return level <= cached.(int)
+	}
+
+	resolved := -1
+	vmoduleMu.RLock()
+	rules := vmoduleRules
+	vmoduleMu.RUnlock()
+
+	base := strings.TrimSuffix(filepath.Base(file), ".go")
+	dir := filepath.ToSlash(filepath.Dir(file))
+	for _, rule := range rules {
+		if pkg, isPkg := strings.CutSuffix(rule.pattern, "/*"); isPkg {
+			if dir == pkg || strings.HasSuffix(dir, "/"+pkg) {
+				resolved = rule.level
+				break
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(rule.pattern, base); matched {
+			resolved = rule.level
+			break
+		}
+	}
+
+	vmoduleCache.Store(pc, resolved)
+	return level <= resolved
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package logging
+
+import "testing"
+
+// callerInThisFile lets tests exercise vEnabledAt as if they were the call
+// site one frame below them, since vmodule rules match on file name.
+func callerInThisFile(level int) bool {
+	return vEnabledAt(level, 2)
+}
+
+func TestSetVModuleInvalidSpec(t *testing.T) {
+	if err := SetVModule("worker"); err == nil {
+		t.Fatal("SetVModule(\"worker\") = nil, want error for missing level")
+	}
+	if err := SetVModule("worker=nope"); err == nil {
+		t.Fatal("SetVModule(\"worker=nope\") = nil, want error for non-numeric level")
+	}
+}
+
+func TestVEnabledAtMatchesByFileName(t *testing.T) {
+	t.Cleanup(func() { SetVModule("") })
+
+	if err := SetVModule("verbosity_test=2"); err != nil {
+		t.Fatalf("SetVModule: %v", err)
+	}
+
+	if !callerInThisFile(2) {
+		t.Error("level 2 should be enabled for verbosity_test.go")
+	}
+	if !callerInThisFile(1) {
+		t.Error("level 1 should be enabled when the rule allows up to 2")
+	}
+	if callerInThisFile(3) {
+		t.Error("level 3 should not be enabled when the rule caps at 2")
+	}
+}
+
+func TestVEnabledAtNoRules(t *testing.T) {
+	t.Cleanup(func() { SetVModule("") })
+
+	if err := SetVModule(""); err != nil {
+		t.Fatalf("SetVModule(\"\"): %v", err)
+	}
+	if callerInThisFile(0) {
+		t.Error("no rules installed, every level should be disabled")
+	}
+}
+
+func TestVEnabledAtPackageGlob(t *testing.T) {
+	t.Cleanup(func() { SetVModule("") })
+
+	if err := SetVModule("nosuchpkg/*=5,verbosity_test=1"); err != nil {
+		t.Fatalf("SetVModule: %v", err)
+	}
+
+	if !callerInThisFile(1) {
+		t.Error("the direct file-name rule should still match alongside an unrelated package glob")
+	}
+	if callerInThisFile(2) {
+		t.Error("level 2 should not be enabled when the matching rule caps at 1")
+	}
+}
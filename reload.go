@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package daemonize
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/PlakarKorp/go-daemonize/logging"
+)
+
+// reloadConfig re-parses daemon.configFile into a fresh Configuration of
+// the same concrete type as the one passed to WithConfiguration,
+// validates it, and only then swaps it in and notifies every service
+// implementing Reloader, in the daemon's recorded startup order. If any
+// service's Reload returns an error, the previous Configuration is
+// restored, every service that had already accepted next is told to
+// Reload back to previous too (best-effort: a failure to roll one of
+// them back is logged but doesn't stop the rest), and the rejection is
+// logged along with the service that caused it. It is the SIGHUP handler
+// in Run, and the control socket's reload command, both funnel through
+// this.
+func (daemon *Daemon) reloadConfig(ctx context.Context, logger logging.Logger) error {
+	if daemon.config == nil || daemon.configFile == "" {
+		return fmt.Errorf("%s: no configuration to reload", daemon.name)
+	}
+
+	next := reflect.New(reflect.TypeOf(daemon.config).Elem()).Interface().(Configuration)
+
+	fp, err := os.Open(daemon.configFile)
+	if err != nil {
+		logger.Error("reload: failed to open %s: %v", daemon.configFile, err)
+		return err
+	}
+	defer fp.Close()
+
+	if err := next.Parse(fp); err != nil {
+		logger.Error("reload: failed to parse %s: %v", daemon.configFile, err)
+		return err
+	}
+	if err := next.Validate(); err != nil {
+		logger.Error("reload: invalid configuration: %v", err)
+		return err
+	}
+
+	daemon.configMu.Lock()
+	previous := daemon.config
+	changes := next.Diff(previous)
+	daemon.config = next
+	daemon.configMu.Unlock()
+
+	for _, change := range changes {
+		logger.Infow("reload: configuration field changed",
+			"field", change.Field, "previous", change.Previous, "current", change.Current)
+	}
+
+	var reloaded []string
+	for _, name := range daemon.serviceOrder {
+		ctrl, ok := daemon.services[name]
+		if !ok {
+			continue
+		}
+		reloader, ok := ctrl.service.(Reloader)
+		if !ok {
+			continue
+		}
+		if err := reloader.Reload(ctx, next); err != nil {
+			daemon.configMu.Lock()
+			daemon.config = previous
+			daemon.configMu.Unlock()
+			logger.Errorw("reload: service rejected configuration, rolling back",
+				"service", name, "error", err)
+
+			for i := len(reloaded) - 1; i >= 0; i-- {
+				rolledBack := daemon.services[reloaded[i]]
+				if rbErr := rolledBack.service.(Reloader).Reload(ctx, previous); rbErr != nil {
+					logger.Errorw("reload: service failed to roll back to previous configuration",
+						"service", reloaded[i], "error", rbErr)
+				}
+			}
+			return fmt.Errorf("service %s rejected configuration, rolled back: %w", name, err)
+		}
+		reloaded = append(reloaded, name)
+	}
+
+	logger.Info("reload: configuration applied")
+	return nil
+}
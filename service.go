@@ -20,12 +20,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/PlakarKorp/go-daemonize/control"
 	"github.com/PlakarKorp/go-daemonize/logging"
+	"github.com/PlakarKorp/go-daemonize/systemd"
 )
 
 type ServiceProvider interface {
@@ -36,6 +40,14 @@ type Service interface {
 	Run(*ServiceController, context.Context) error
 }
 
+// Reloader is implemented by services that want to react to a
+// successful configuration reload (see Daemon.reloadConfig, triggered by
+// SIGHUP). A service that doesn't implement it just keeps running
+// unchanged across a reload.
+type Reloader interface {
+	Reload(ctx context.Context, cfg Configuration) error
+}
+
 type ServiceStatus string
 
 const (
@@ -52,21 +64,111 @@ type ServiceController struct {
 	status  ServiceStatus
 	mu      sync.Mutex
 	stop    context.CancelCauseFunc
+	daemon  *Daemon
+
+	loggerMu sync.RWMutex
+	logger   logging.Logger
+
+	deps       []string
+	depCtrls   []*ServiceController
+	dependents []*ServiceController
+
+	upCh   chan struct{}
+	upOnce sync.Once
+	done   chan struct{}
+
+	retries int
+}
+
+// prefixer is implemented by Logger concrete types that support deriving a
+// copy scoped to a prefix, e.g. a service name.
+type prefixer interface {
+	WithPrefix(prefix string, args ...any) logging.Logger
+}
+
+// leveler is implemented by Logger concrete types that support producing
+// a copy of themselves at a different level, instead of mutating the
+// original in place. ServiceController.SetLevel relies on this: the
+// running service's goroutine may be logging through the old value
+// concurrently, so swapping in a new one is the only race-free way to
+// change its level.
+type leveler interface {
+	WithLevel(level logging.LogLevel) logging.Logger
+}
+
+func loggerForService(base logging.Logger, name string) logging.Logger {
+	if p, ok := base.(prefixer); ok {
+		return p.WithPrefix(name)
+	}
+	return base
+}
+
+// getLogger returns this service's current logger, synchronized against
+// SetLevel swapping it out concurrently.
+func (ctrl *ServiceController) getLogger() logging.Logger {
+	ctrl.loggerMu.RLock()
+	defer ctrl.loggerMu.RUnlock()
+	return ctrl.logger
+}
+
+func (ctrl *ServiceController) setLogger(l logging.Logger) {
+	ctrl.loggerMu.Lock()
+	defer ctrl.loggerMu.Unlock()
+	ctrl.logger = l
 }
 
+// Stopped is the cancel cause used for an intentional stop that the
+// service's own RestartPolicy is still allowed to act on: cascadeRestart
+// cancels with it so that a RestartAlways dependent comes back up, while
+// ServiceController.Run (the common "just block until stopped" case) uses
+// it to return a nil error instead of ctx.Err().
 var Stopped = errors.New("stopped")
 
-func (daemon *Daemon) Run(ctx context.Context) {
-	var wg sync.WaitGroup
+// stoppedForGood is the cancel cause for a stop that must never be
+// reinterpreted as grounds to restart, no matter the RestartPolicy:
+// daemon shutdown and the control socket's stop command both use it, so
+// that run's restart decision treats the service as gone for good. To
+// service code it means exactly what Stopped means (a clean, intentional
+// exit), so ServiceController.Run treats the two identically.
+var stoppedForGood = errors.New("stopped for good")
 
+func (daemon *Daemon) Run(ctx context.Context) {
 	logger := logging.GetLogger(ctx)
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	ctx = context.WithValue(ctx, serviceProviderKey, daemon)
+	daemon.ctx = ctx
+
+	if daemon.controlSocket != "" {
+		daemon.control = control.NewServer(daemon.controlSocket, daemon)
+		if err := daemon.control.ListenAndServe(); err != nil {
+			logger.Error("failed to start control socket: %v", err)
+		} else {
+			defer daemon.control.Close()
+		}
+	}
+
+	if daemon.notifier.Enabled() {
+		if interval, ok := systemd.WatchdogInterval(); ok {
+			stopWatchdog := make(chan struct{})
+			defer close(stopWatchdog)
+			go daemon.runWatchdog(interval, stopWatchdog)
+		}
+	}
+
+	order, err := daemon.topoSortServices()
+	if err != nil {
+		logger.Error("failed to order services: %v", err)
+		return
+	}
+	daemon.serviceOrder = order
+	daemon.wireServiceGraph()
 
 	ok := true
-	for _, ctrl := range daemon.services {
-		if err := ctrl.startService(ctx, &wg); err != nil {
-			logger.Error("failed to start service %s: %v", err)
+	for _, name := range order {
+		if err := daemon.services[name].startService(ctx, &daemon.wg); err != nil {
+			logger.Error("failed to start service %s: %v", name, err)
 			ok = false
 			break
 		}
@@ -74,30 +176,88 @@ func (daemon *Daemon) Run(ctx context.Context) {
 
 	if ok {
 		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-		sig := <-quit
-		logger.Info("got signal %v", sig)
-		logger.Info("shutting down...")
+		for {
+			sig := <-quit
+			if sig == syscall.SIGHUP {
+				daemon.reopenLogs(logger)
+				_ = daemon.reloadConfig(ctx, logger)
+				continue
+			}
+			logger.Info("got signal %v", sig)
+			logger.Info("shutting down...")
+			daemon.notifier.Stopping()
+			break
+		}
 	}
 
-	for _, ctrl := range daemon.services {
-		ctrl.stopService()
+	for i := len(order) - 1; i >= 0; i-- {
+		daemon.services[order[i]].stopService(daemon.stopGrace, stoppedForGood)
 	}
 
-	wg.Wait()
+	// Services that were not running above (still waiting on a dependency,
+	// or sleeping before a restart) see ctx cancelled and return.
+	cancel()
+	daemon.wg.Wait()
 	logging.GetLogger(ctx).Info("exiting")
+
+	if daemon.logFlusher != nil {
+		daemon.logFlusher.Close()
+	}
 }
 
-func (daemon *Daemon) AddService(name string, service Service) {
-	if daemon.services == nil {
-		daemon.services = make(map[string]*ServiceController)
+// wireServiceGraph resolves each service's textual deps into the live
+// ServiceControllers they name, in both directions, so that startService
+// can wait on a dependency's upCh and a failed service can cascade a
+// restart to whatever depends on it.
+func (daemon *Daemon) wireServiceGraph() {
+	for _, ctrl := range daemon.services {
+		ctrl.depCtrls = nil
+		ctrl.dependents = nil
+	}
+	for _, ctrl := range daemon.services {
+		for _, dep := range ctrl.deps {
+			ctrl.depCtrls = append(ctrl.depCtrls, daemon.services[dep])
+		}
+	}
+	for _, ctrl := range daemon.services {
+		for _, dep := range ctrl.depCtrls {
+			dep.dependents = append(dep.dependents, ctrl)
+		}
 	}
-	daemon.services[name] = &ServiceController{
-		name:    name,
-		service: service,
-		status:  ServiceDown,
-		mu:      sync.Mutex{},
+}
+
+// runWatchdog pings systemd's watchdog timer every interval until stop is
+// closed.
+func (daemon *Daemon) runWatchdog(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			daemon.notifier.Watchdog()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// notifyStatus reports the aggregate up/total service count to systemd as
+// STATUS, and signals READY once every registered service is up.
+func (daemon *Daemon) notifyStatus() {
+	if !daemon.notifier.Enabled() {
+		return
+	}
+	up, total := 0, len(daemon.services)
+	for _, ctrl := range daemon.services {
+		if ctrl.Status() == ServiceUp {
+			up++
+		}
+	}
+	daemon.notifier.Status(fmt.Sprintf("%d/%d services up", up, total))
+	if total > 0 && up == total {
+		daemon.notifier.Ready()
 	}
 }
 
@@ -110,65 +270,265 @@ func (daemon *Daemon) GetService(name string) Service {
 }
 
 func (ctrl *ServiceController) startService(ctx context.Context, wg *sync.WaitGroup) error {
-	ctrl.ctx = ctx
 	ctrl.mu.Lock()
 	if ctrl.status != ServiceDown {
 		err := fmt.Errorf("service is %s", ctrl.status)
 		ctrl.mu.Unlock()
 		return err
 	}
-
-	logging.GetLogger(ctx).Info("%s: starting...", ctrl.name)
+	ctrl.ctx = ctx
+	if ctrl.getLogger() == nil {
+		ctrl.setLogger(loggerForService(logging.GetLogger(ctx), ctrl.name))
+	}
 	ctrl.status = ServiceStarting
 	ctrl.mu.Unlock()
 
-	go func() {
+	wg.Add(1)
+	go ctrl.run(ctx, wg)
+	return nil
+}
+
+// run waits for every dependency to report Up, then runs the service,
+// restarting it with backoff according to its RestartPolicy for as long
+// as the daemon isn't shutting down.
+func (ctrl *ServiceController) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for _, dep := range ctrl.depCtrls {
+		select {
+		case <-dep.upCh:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	for {
+		ctrl.getLogger().Info("starting...")
+		ctrl.mu.Lock()
+		ctrl.status = ServiceStarting
+		done := make(chan struct{})
+		ctrl.done = done
+		ctrl.mu.Unlock()
+
 		serviceCtx, cancel := context.WithCancelCause(ctx)
+		ctrl.mu.Lock()
 		ctrl.stop = cancel
+		ctrl.mu.Unlock()
+
 		err := ctrl.service.Run(ctrl, serviceCtx)
+		cause := context.Cause(serviceCtx)
+
+		ctrl.mu.Lock()
 		ctrl.stop = nil
+		ctrl.status = ServiceDown
+		ctrl.mu.Unlock()
+		close(done)
+
 		if err != nil {
-			logging.GetLogger(ctx).Warn("service %s returned error: %v", ctrl.name, err)
+			ctrl.getLogger().Warn("returned error: %v", err)
+		} else {
+			ctrl.getLogger().Info("stopped")
 		}
-		ctrl.mu.Lock()
-		ctrl.status = ServiceStopping
-		logging.GetLogger(ctx).Info("%s: stopped", ctrl.name)
-		ctrl.mu.Unlock()
-		wg.Done()
-	}()
 
-	wg.Add(1)
+		if ctx.Err() != nil || cause == stoppedForGood {
+			return
+		}
+		if !ctrl.shouldRestart(err) {
+			return
+		}
+
+		delay := ctrl.nextRestartDelay()
+		ctrl.getLogger().Info("restarting in %s", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+
+		ctrl.daemon.cascadeRestart(ctrl)
+	}
+}
+
+// shouldRestart reports whether ctrl should be restarted after Run
+// returned err, according to the service's RestartPolicy (RestartNever if
+// it doesn't implement Restartable) and the daemon's restart retry
+// budget.
+func (ctrl *ServiceController) shouldRestart(err error) bool {
+	policy := RestartNever
+	if r, ok := ctrl.service.(Restartable); ok {
+		policy = r.RestartPolicy()
+	}
+	switch policy {
+	case RestartAlways:
+		return ctrl.withinRetryBudget()
+	case RestartOnFailure:
+		return err != nil && ctrl.withinRetryBudget()
+	default:
+		return false
+	}
+}
+
+func (ctrl *ServiceController) withinRetryBudget() bool {
+	max := ctrl.daemon.restartMaxRetries
+	if max < 0 {
+		return true
+	}
+	return ctrl.retries < max
+}
+
+// nextRestartDelay returns the next backoff delay and bumps the retry
+// counter. The delay doubles every attempt, up to the daemon's configured
+// maximum, and is jittered by up to half its value so that dependents
+// restarting together don't thunder in lockstep.
+func (ctrl *ServiceController) nextRestartDelay() time.Duration {
+	ctrl.retries++
+
+	base := ctrl.daemon.restartBaseDelay
+	if base <= 0 {
+		base = defaultRestartBaseDelay
+	}
+	max := ctrl.daemon.restartMaxDelay
+	if max <= 0 {
+		max = defaultRestartMaxDelay
+	}
+
+	shift := ctrl.retries - 1
+	if shift > 20 {
+		shift = 20
+	}
+	delay := base * time.Duration(int64(1)<<uint(shift))
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return delay/2 + jitter
+}
+
+// cascadeRestart stops every direct dependent of ctrl that will actually
+// come back up on its own, so that services relying on ctrl's
+// freshly-restarted state don't keep running against stale assumptions.
+// stopService cancels with the Stopped cause, which makes Run return a
+// nil error, so only RestartAlways dependents are eligible here: a
+// RestartOnFailure dependent wouldn't restart after a nil-error exit, and
+// a RestartNever one (the default for any service that doesn't implement
+// Restartable) would never come back at all. Dependents with those
+// policies are left running untouched.
+func (daemon *Daemon) cascadeRestart(ctrl *ServiceController) {
+	for _, dependent := range ctrl.dependents {
+		r, ok := dependent.service.(Restartable)
+		if !ok || r.RestartPolicy() != RestartAlways {
+			continue
+		}
+		dependent.getLogger().Info("restarting because dependency %s restarted", ctrl.name)
+		dependent.stopService(daemon.stopGrace, Stopped)
+	}
+}
+
+// SetLevel overrides the log level of this service's own logger, without
+// affecting the daemon's default logger or other services. It swaps in a
+// copy at the new level rather than mutating the existing logger in
+// place, since the service's own goroutine may be logging through it
+// concurrently.
+func (ctrl *ServiceController) SetLevel(level logging.LogLevel) error {
+	current := ctrl.getLogger()
+	if current == nil {
+		return fmt.Errorf("service %s: not started yet", ctrl.name)
+	}
+	lvl, ok := current.(leveler)
+	if !ok {
+		current.SetLevel(level)
+		return nil
+	}
+	ctrl.setLogger(lvl.WithLevel(level))
 	return nil
 }
 
-func (ctrl *ServiceController) stopService() {
-	if ctrl.stop != nil {
-		ctrl.stop(Stopped)
-		ctrl.stop = nil
+// stopService cancels the running service, if any, with cause, and waits
+// up to grace for it to return before giving up and logging a warning
+// (the service's goroutine is still tracked by the daemon's WaitGroup and
+// may finish later). Pass stoppedForGood for a stop that must stick
+// (daemon shutdown, the control socket's stop command); pass Stopped for
+// one a RestartAlways dependent should come back from (cascadeRestart).
+func (ctrl *ServiceController) stopService(grace time.Duration, cause error) {
+	ctrl.mu.Lock()
+	stop := ctrl.stop
+	done := ctrl.done
+	ctrl.mu.Unlock()
+
+	if stop == nil {
+		return
 	}
+	stop(cause)
+
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(grace):
+		ctrl.getLogger().Warn("did not stop within %s", grace)
+	}
+}
+
+// Status returns this service's current status.
+func (ctrl *ServiceController) Status() ServiceStatus {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+	return ctrl.status
 }
 
 func (ctrl *ServiceController) Up() {
+	ctrl.getLogger().Info("up")
 	ctrl.mu.Lock()
 	ctrl.status = ServiceUp
-	logging.GetLogger(ctrl.ctx).Info("%s: up", ctrl.name)
+	done := ctrl.done
 	ctrl.mu.Unlock()
+
+	ctrl.upOnce.Do(func() { close(ctrl.upCh) })
+	if ctrl.daemon != nil {
+		ctrl.daemon.notifyStatus()
+	}
+
+	// A service that stays up longer than its own backoff ceiling has
+	// clearly recovered, so forget about its past failures: otherwise a
+	// long-lived service that crashes occasionally but always recovers
+	// would eventually exhaust a finite maxRetries for good, even though
+	// the incidents were unrelated and far apart.
+	time.AfterFunc(ctrl.daemon.restartResetAfter(), func() {
+		ctrl.mu.Lock()
+		if ctrl.status == ServiceUp && ctrl.done == done {
+			ctrl.retries = 0
+		}
+		ctrl.mu.Unlock()
+	})
 }
 
 func (ctrl *ServiceController) Stopping() {
 	ctrl.mu.Lock()
 	ctrl.status = ServiceStopping
-	logging.GetLogger(ctrl.ctx).Info("%s: stopping", ctrl.name)
 	ctrl.mu.Unlock()
+	ctrl.getLogger().Info("stopping")
+	if ctrl.daemon != nil {
+		ctrl.daemon.notifyStatus()
+	}
+}
+
+// Logger returns this service's own logger, scoped with its name and
+// independently levelled via the control socket's loglevel command.
+func (ctrl *ServiceController) Logger() logging.Logger {
+	return ctrl.getLogger()
 }
 
 func (ctrl *ServiceController) Run(ctx context.Context) error {
 	ctrl.Up()
 	<-ctx.Done()
-	if context.Cause(ctx) == Stopped {
+	switch context.Cause(ctx) {
+	case Stopped, stoppedForGood:
 		return nil
+	default:
+		return ctx.Err()
 	}
-	return ctx.Err()
 }
 
 type key int
@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package daemonize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RestartPolicy controls what happens when a service's Run method
+// returns.
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts a stopped service. This is the
+	// default for services that don't implement Restartable.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the service when Run returns a non-nil
+	// error, but not on a clean exit.
+	RestartOnFailure
+	// RestartAlways restarts the service whenever Run returns, clean
+	// exit or not.
+	RestartAlways
+)
+
+// Restartable is implemented by services that want to be restarted
+// automatically when they exit, with exponential backoff between
+// attempts (see WithRestartBackoff).
+type Restartable interface {
+	RestartPolicy() RestartPolicy
+}
+
+// restartResetAfter returns how long a service must stay Up before its
+// restart counter is forgiven (see ServiceController.Up), defaulting to
+// the same ceiling used to cap backoff delays.
+func (daemon *Daemon) restartResetAfter() time.Duration {
+	if daemon.restartMaxDelay > 0 {
+		return daemon.restartMaxDelay
+	}
+	return defaultRestartMaxDelay
+}
+
+// AddServiceWithDeps registers service under name, like AddService, but
+// additionally records that it depends on the services named in deps:
+// at startup it is only started once those have reported Up, and at
+// shutdown it is stopped before any of them. It returns an error without
+// registering anything if doing so would introduce a dependency cycle.
+func (daemon *Daemon) AddServiceWithDeps(name string, service Service, deps ...string) error {
+	if daemon.depGraph == nil {
+		daemon.depGraph = make(map[string][]string)
+	}
+	previous, hadPrevious := daemon.depGraph[name]
+	daemon.depGraph[name] = deps
+
+	if cycle := findCycle(daemon.depGraph); cycle != nil {
+		if hadPrevious {
+			daemon.depGraph[name] = previous
+		} else {
+			delete(daemon.depGraph, name)
+		}
+		return fmt.Errorf("service %s: dependency cycle: %s", name, strings.Join(cycle, " -> "))
+	}
+
+	daemon.addService(name, service, deps)
+	return nil
+}
+
+// AddService registers service under name with no dependencies.
+func (daemon *Daemon) AddService(name string, service Service) {
+	// A service with no deps can never introduce a cycle.
+	_ = daemon.AddServiceWithDeps(name, service)
+}
+
+func (daemon *Daemon) addService(name string, service Service, deps []string) {
+	if daemon.services == nil {
+		daemon.services = make(map[string]*ServiceController)
+	}
+	daemon.services[name] = &ServiceController{
+		name:    name,
+		service: service,
+		status:  ServiceDown,
+		daemon:  daemon,
+		deps:    deps,
+		upCh:    make(chan struct{}),
+	}
+}
+
+// findCycle runs a DFS over graph (name -> deps) and returns the nodes
+// making up a cycle, or nil if the graph is acyclic. Dependencies that
+// don't (yet) have their own entry in graph are treated as leaves.
+func findCycle(graph map[string][]string) []string {
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make(map[string]int)
+	var path []string
+	var cycle []string
+
+	var visit func(node string)
+	visit = func(node string) {
+		color[node] = gray
+		path = append(path, node)
+		for _, dep := range graph[node] {
+			if cycle != nil {
+				return
+			}
+			switch color[dep] {
+			case gray:
+				for i, n := range path {
+					if n == dep {
+						cycle = append(append([]string{}, path[i:]...), dep)
+						return
+					}
+				}
+			case white:
+				visit(dep)
+			}
+		}
+		if cycle == nil {
+			path = path[:len(path)-1]
+			color[node] = black
+		}
+	}
+
+	names := make([]string, 0, len(graph))
+	for node := range graph {
+		names = append(names, node)
+	}
+	sort.Strings(names)
+
+	for _, node := range names {
+		if color[node] == white {
+			visit(node)
+			if cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// topoSortServices returns the names of daemon.services in dependency
+// order (a dependency always comes before whatever depends on it). It
+// fails if a dependency refers to a service that was never registered,
+// or if the graph has a cycle (which AddServiceWithDeps should already
+// have rejected).
+func (daemon *Daemon) topoSortServices() ([]string, error) {
+	indegree := make(map[string]int, len(daemon.services))
+	dependents := make(map[string][]string)
+
+	for name := range daemon.services {
+		indegree[name] = 0
+	}
+	for name, ctrl := range daemon.services {
+		for _, dep := range ctrl.deps {
+			if _, found := daemon.services[dep]; !found {
+				return nil, fmt.Errorf("service %s: unknown dependency %s", name, dep)
+			}
+			indegree[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var queue []string
+	for name, deg := range indegree {
+		if deg == 0 {
+			queue = append(queue, name)
+		}
+	}
+	sort.Strings(queue)
+
+	var order []string
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		order = append(order, name)
+
+		next := append([]string{}, dependents[name]...)
+		sort.Strings(next)
+		for _, n := range next {
+			indegree[n]--
+			if indegree[n] == 0 {
+				queue = append(queue, n)
+				sort.Strings(queue)
+			}
+		}
+	}
+
+	if len(order) != len(daemon.services) {
+		return nil, fmt.Errorf("service dependency graph has a cycle")
+	}
+	return order, nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package daemonize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindCycleAcyclic(t *testing.T) {
+	graph := map[string][]string{
+		"web":    {"cache", "db"},
+		"cache":  {"db"},
+		"db":     nil,
+		"worker": {"db"},
+	}
+	if cycle := findCycle(graph); cycle != nil {
+		t.Fatalf("findCycle() = %v, want nil", cycle)
+	}
+}
+
+func TestFindCycleDetectsSelfLoop(t *testing.T) {
+	graph := map[string][]string{"web": {"web"}}
+	cycle := findCycle(graph)
+	if cycle == nil {
+		t.Fatal("findCycle() = nil, want a cycle for a self-dependency")
+	}
+}
+
+func TestFindCycleDetectsLongerCycle(t *testing.T) {
+	graph := map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a"},
+	}
+	cycle := findCycle(graph)
+	if cycle == nil {
+		t.Fatal("findCycle() = nil, want a cycle for a -> b -> c -> a")
+	}
+	joined := strings.Join(cycle, " -> ")
+	for _, name := range []string{"a", "b", "c"} {
+		if !strings.Contains(joined, name) {
+			t.Errorf("cycle %v does not mention %s", cycle, name)
+		}
+	}
+}
+
+// newTestDaemon builds a Daemon with services registered directly (bypassing
+// AddServiceWithDeps, which already has its own cycle-rejection behavior
+// covered by TestFindCycle*), so topoSortServices can be tested in isolation.
+func newTestDaemon(services map[string][]string) *Daemon {
+	daemon := &Daemon{
+		services: make(map[string]*ServiceController, len(services)),
+	}
+	for name, deps := range services {
+		daemon.services[name] = &ServiceController{name: name, deps: deps}
+	}
+	return daemon
+}
+
+func indexOf(order []string, name string) int {
+	for i, n := range order {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestTopoSortServicesOrdersDependenciesFirst(t *testing.T) {
+	daemon := newTestDaemon(map[string][]string{
+		"web":    {"cache", "db"},
+		"cache":  {"db"},
+		"db":     nil,
+		"worker": {"db"},
+	})
+
+	order, err := daemon.topoSortServices()
+	if err != nil {
+		t.Fatalf("topoSortServices() error = %v", err)
+	}
+	if len(order) != 4 {
+		t.Fatalf("topoSortServices() = %v, want 4 entries", order)
+	}
+
+	dbIdx, cacheIdx, webIdx, workerIdx := indexOf(order, "db"), indexOf(order, "cache"), indexOf(order, "web"), indexOf(order, "worker")
+	if dbIdx > cacheIdx || dbIdx > webIdx || dbIdx > workerIdx {
+		t.Errorf("db must come before its dependents, got order %v", order)
+	}
+	if cacheIdx > webIdx {
+		t.Errorf("cache must come before web, got order %v", order)
+	}
+}
+
+func TestTopoSortServicesUnknownDependency(t *testing.T) {
+	daemon := newTestDaemon(map[string][]string{
+		"web": {"missing"},
+	})
+
+	if _, err := daemon.topoSortServices(); err == nil {
+		t.Fatal("topoSortServices() error = nil, want error for unknown dependency")
+	}
+}
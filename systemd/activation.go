@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listenFDsStart is the first file descriptor systemd hands to a
+// socket-activated process, per sd_listen_fds(3).
+const listenFDsStart = 3
+
+// Activated reports whether this process received sockets via systemd
+// socket activation.
+func Activated() bool {
+	return os.Getenv("LISTEN_FDS") != "" && listenPIDMatches()
+}
+
+func listenPIDMatches() bool {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	return err == nil && pid == os.Getpid()
+}
+
+// Listeners returns the sockets systemd passed this process, keyed by the
+// name assigned via FileDescriptorName= in the unit (from LISTEN_FDNAMES),
+// or by their positional index ("0", "1", ...) when no name was assigned.
+func Listeners() (map[string]net.Listener, error) {
+	listeners := map[string]net.Listener{}
+	if !Activated() {
+		return listeners, nil
+	}
+
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil, fmt.Errorf("systemd: invalid LISTEN_FDS: %w", err)
+	}
+
+	var names []string
+	if fdnames := os.Getenv("LISTEN_FDNAMES"); fdnames != "" {
+		names = strings.Split(fdnames, ":")
+	}
+
+	for i := 0; i < nfds; i++ {
+		fd := listenFDsStart + i
+		syscall.CloseOnExec(fd)
+
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("listener%d", i))
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("systemd: fd %d: %w", fd, err)
+		}
+
+		name := strconv.Itoa(i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		listeners[name] = listener
+	}
+	return listeners, nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2025 Eric Faurot <eric.faurot@plakar.io>
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package systemd implements the sd_notify protocol and socket activation
+// used by systemd's Type=notify services, without depending on libsystemd.
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notifier sends sd_notify datagrams to the socket systemd passed this
+// process in NOTIFY_SOCKET. A Notifier obtained outside of systemd (or when
+// NOTIFY_SOCKET isn't set) is valid but Enabled reports false, and every
+// notification is a no-op.
+type Notifier struct {
+	addr *net.UnixAddr
+}
+
+// NewNotifier builds a Notifier from the current environment.
+func NewNotifier() *Notifier {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return &Notifier{}
+	}
+	// An abstract socket address is spelled with a leading '@' in the
+	// environment and a leading NUL on the wire.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	return &Notifier{addr: &net.UnixAddr{Name: socket, Net: "unixgram"}}
+}
+
+// Enabled reports whether this process was started with a NOTIFY_SOCKET.
+func (n *Notifier) Enabled() bool {
+	return n != nil && n.addr != nil
+}
+
+func (n *Notifier) notify(state string) error {
+	if !n.Enabled() {
+		return nil
+	}
+	conn, err := net.DialUnix(n.addr.Net, nil, n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// Ready tells systemd the service finished starting up.
+func (n *Notifier) Ready() error {
+	return n.notify("READY=1")
+}
+
+// Status sets the free-form status text shown by "systemctl status".
+func (n *Notifier) Status(status string) error {
+	return n.notify("STATUS=" + status)
+}
+
+// Reloading tells systemd a configuration reload is in progress; the
+// caller must follow up with Ready once the reload completes.
+func (n *Notifier) Reloading() error {
+	return n.notify("RELOADING=1")
+}
+
+// Stopping tells systemd the service is shutting down.
+func (n *Notifier) Stopping() error {
+	return n.notify("STOPPING=1")
+}
+
+// Watchdog pings systemd's watchdog timer.
+func (n *Notifier) Watchdog() error {
+	return n.notify("WATCHDOG=1")
+}
+
+// WatchdogInterval returns how often Watchdog should be called, derived
+// from WATCHDOG_USEC as systemd.service(5) recommends (at most half the
+// configured timeout), and ok=false if no watchdog is configured.
+func WatchdogInterval() (interval time.Duration, ok bool) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Microsecond / 2, true
+}